@@ -0,0 +1,158 @@
+// Copyright 2023 The Cloudprober Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cloudprober/cloudprober/common/strtemplate"
+	"github.com/cloudprober/cloudprober/logger"
+	"github.com/google/shlex"
+)
+
+// cmdExitError wraps a command's exit code so recordNotifyResult can surface
+// it as a metric without every Notifier needing to know about notifierStat.
+type cmdExitError struct {
+	code int
+	err  error
+}
+
+func (e *cmdExitError) Error() string { return e.err.Error() }
+
+// alertEnv turns the alertFields map into CLOUDPROBER_ALERT_* environment
+// variables (uppercased, non-alphanumerics replaced with "_"), so command
+// notifiers can read alert data without relying on templated argv. Keys are
+// sorted first so that any sanitized-name collision (e.g. two target labels
+// differing only by case or punctuation) resolves deterministically rather
+// than depending on map iteration order, and is logged instead of silent.
+func alertEnv(l *logger.Logger, fields map[string]string) []string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	env := os.Environ()
+	seen := make(map[string]string, len(fields))
+	for _, k := range keys {
+		name := strings.Map(func(r rune) rune {
+			if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+				return r
+			}
+			return '_'
+		}, strings.ToUpper(k))
+
+		if prev, ok := seen[name]; ok {
+			l.Warningf("alert field %q collides with %q in CLOUDPROBER_ALERT_%s; using %q", k, prev, name, k)
+		}
+		seen[name] = k
+
+		env = append(env, fmt.Sprintf("CLOUDPROBER_ALERT_%s=%s", name, fields[k]))
+	}
+	return env
+}
+
+// runCommand execs cmdParts with fields injected as CLOUDPROBER_ALERT_* env
+// vars, waits for it to finish (bounded by ctx), and logs captured
+// stdout/stderr at debug level. The returned error wraps the exit code, if
+// any, so callers can surface it as a metric.
+func runCommand(ctx context.Context, l *logger.Logger, cmdParts []string, fields map[string]string) error {
+	if len(cmdParts) == 0 {
+		return fmt.Errorf("empty command")
+	}
+
+	cmd := exec.CommandContext(ctx, cmdParts[0], cmdParts[1:]...)
+	cmd.Env = alertEnv(l, fields)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	l.Infof("Starting external command: %s", strings.Join(cmdParts, " "))
+
+	err := cmd.Run()
+
+	l.Debugf("command (%s) stdout: %s", cmdParts[0], stdout.String())
+	l.Debugf("command (%s) stderr: %s", cmdParts[0], stderr.String())
+
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return &cmdExitError{code: exitErr.ExitCode(), err: fmt.Errorf("command (%s) exited with code %d: %v", cmdParts[0], exitErr.ExitCode(), err)}
+		}
+		return fmt.Errorf("error running command (%s): %v", cmdParts[0], err)
+	}
+	return nil
+}
+
+// legacyCommandNotifier runs NotifyConfig.command, a single templated
+// string split with shell-like quoting rules via shlex. Fragile when
+// target.label.* values contain shell metacharacters; prefer
+// argvCommandNotifier. Kept for backward compatibility.
+type legacyCommandNotifier struct {
+	command string
+	l       *logger.Logger
+
+	warnOnce sync.Once
+}
+
+func (n *legacyCommandNotifier) Name() string { return "command" }
+
+func (n *legacyCommandNotifier) Notify(ctx context.Context, fields map[string]string) error {
+	n.warnOnce.Do(func() {
+		n.l.Warningf("notify.command is deprecated and fragile with untrusted label values; switch to notify.argv")
+	})
+
+	command, foundAll := strtemplate.SubstituteLabels(n.command, fields)
+	if !foundAll {
+		n.l.Warningf("couldn't substitute all labels in command: %s", n.command)
+	}
+
+	cmdParts, err := shlex.Split(command)
+	if err != nil {
+		return fmt.Errorf("error parsing command line (%s): %v", command, err)
+	}
+
+	return runCommand(ctx, n.l, cmdParts, fields)
+}
+
+// argvCommandNotifier runs an argv-form command where each argument is
+// templated independently, so no shell parsing is needed and label values
+// can't break out of an argument.
+type argvCommandNotifier struct {
+	argv []string
+	l    *logger.Logger
+}
+
+func (n *argvCommandNotifier) Name() string { return "argv" }
+
+func (n *argvCommandNotifier) Notify(ctx context.Context, fields map[string]string) error {
+	cmdParts := make([]string, len(n.argv))
+	for i, arg := range n.argv {
+		substituted, foundAll := strtemplate.SubstituteLabels(arg, fields)
+		if !foundAll {
+			n.l.Warningf("couldn't substitute all labels in argv[%d]: %s", i, arg)
+		}
+		cmdParts[i] = substituted
+	}
+
+	return runCommand(ctx, n.l, cmdParts, fields)
+}
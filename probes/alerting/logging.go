@@ -0,0 +1,52 @@
+// Copyright 2023 The Cloudprober Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alerting
+
+import (
+	"sort"
+	"strings"
+)
+
+// logStructuredAlert logs one line per alert event with every alertFields
+// entry except the combined "json" one rendered as a key=value pair, sorted
+// by key, at the alert's configured severity
+// ("info", "warning", or "critical"; default "warning"). This replaces the
+// single free-form ALERT log line with output a log pipeline can parse
+// field-by-field.
+func (ah *AlertHandler) logStructuredAlert(fields map[string]string) {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		if k == "json" {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + fields[k]
+	}
+	line := strings.Join(pairs, " ")
+
+	switch ah.severity {
+	case "info":
+		ah.l.Infof("%s", line)
+	case "critical":
+		ah.l.Errorf("%s", line)
+	default:
+		ah.l.Warningf("%s", line)
+	}
+}
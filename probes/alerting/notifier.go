@@ -0,0 +1,176 @@
+// Copyright 2023 The Cloudprober Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alerting
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/cloudprober/cloudprober/logger"
+	alertingpb "github.com/cloudprober/cloudprober/probes/alerting/proto"
+)
+
+// Notifier is the interface implemented by every alert sink: Slack,
+// PagerDuty, email, generic webhooks, the legacy command path, etc. A
+// Notifier is built once from its NotifyConfig and reused for the lifetime
+// of the AlertHandler.
+type Notifier interface {
+	// Name identifies the notifier in logs and metrics, e.g. "slack" or
+	// "webhook".
+	Name() string
+
+	// Notify delivers a single alert. fields is the same alertFields map
+	// used by the command path, keyed by field name (e.g. "target",
+	// "condition_id", "target.label.region").
+	Notify(ctx context.Context, fields map[string]string) error
+}
+
+// newNotifier builds a Notifier from a NotifyConfig. It returns nil, nil if
+// the config doesn't select any known notifier (e.g. it's empty).
+func newNotifier(nc *alertingpb.NotifyConfig, l *logger.Logger) (Notifier, error) {
+	switch {
+	case nc.GetSlack() != nil:
+		return newSlackNotifier(nc.GetSlack(), nc.GetTemplate()), nil
+	case nc.GetPagerduty() != nil:
+		return newPagerdutyNotifier(nc.GetPagerduty()), nil
+	case nc.GetEmail() != nil:
+		return newEmailNotifier(nc.GetEmail(), nc.GetTemplate()), nil
+	case nc.GetWebhook() != nil:
+		return newWebhookNotifier(nc.GetWebhook(), nc.GetTemplate()), nil
+	case nc.GetAlertmanager() != nil:
+		return newAlertmanagerNotifier(nc.GetAlertmanager()), nil
+	case nc.GetJsonFile() != nil:
+		return newJSONFileNotifier(nc.GetJsonFile()), nil
+	case nc.GetArgv() != nil:
+		return &argvCommandNotifier{argv: nc.GetArgv().GetArgv(), l: l}, nil
+	case nc.GetCommand() != "":
+		return &legacyCommandNotifier{command: nc.GetCommand(), l: l}, nil
+	default:
+		return nil, nil
+	}
+}
+
+// notifierTimeout returns the per-notifier timeout configured on nc,
+// defaulting to 10s to match the proto default.
+func notifierTimeout(nc *alertingpb.NotifyConfig) time.Duration {
+	sec := nc.GetTimeoutSec()
+	if sec <= 0 {
+		sec = 10
+	}
+	return time.Duration(sec) * time.Second
+}
+
+// dispatch runs every configured notifier concurrently against the given
+// alert fields, each bounded by its own timeout, and records per-notifier
+// success/failure metrics. It returns once all notifiers have either
+// finished or timed out.
+//
+// repeatKey identifies the firing episode (condition + target) for
+// RepeatInterval bookkeeping. When isRepeat is true, a notifier without a
+// configured RepeatInterval is skipped, and one with a RepeatInterval is
+// skipped until that interval has elapsed since its last send.
+func (ah *AlertHandler) dispatch(ctx context.Context, repeatKey string, fields map[string]string, isRepeat bool) {
+	if len(ah.notifiers) == 0 {
+		return
+	}
+
+	var wg sync.WaitGroup
+	for i, n := range ah.notifiers {
+		nc := ah.notifyConfigs[i]
+
+		if isRepeat && !ah.dueForRepeat(repeatKey, n.Name(), nc) {
+			continue
+		}
+
+		wg.Add(1)
+		go func(n Notifier, nc *alertingpb.NotifyConfig) {
+			defer wg.Done()
+
+			nctx, cancel := context.WithTimeout(ctx, notifierTimeout(nc))
+			defer cancel()
+
+			err := n.Notify(nctx, fields)
+			ah.recordNotifyResult(n.Name(), err)
+			ah.markNotified(repeatKey, n.Name())
+		}(n, nc)
+	}
+	wg.Wait()
+}
+
+// dueForRepeat reports whether a repeat-firing alert should re-notify n,
+// based on nc's RepeatInterval and when n last notified for repeatKey.
+func (ah *AlertHandler) dueForRepeat(repeatKey, notifierName string, nc *alertingpb.NotifyConfig) bool {
+	interval := nc.GetRepeatIntervalSec()
+	if interval <= 0 {
+		return false
+	}
+
+	ah.notifierMu.Lock()
+	defer ah.notifierMu.Unlock()
+
+	if ah.lastNotifyAt == nil {
+		return true
+	}
+	last, ok := ah.lastNotifyAt[repeatKey+"/"+notifierName]
+	if !ok {
+		return true
+	}
+	return time.Since(last) >= time.Duration(interval)*time.Second
+}
+
+func (ah *AlertHandler) markNotified(repeatKey, notifierName string) {
+	ah.notifierMu.Lock()
+	defer ah.notifierMu.Unlock()
+
+	if ah.lastNotifyAt == nil {
+		ah.lastNotifyAt = make(map[string]time.Time)
+	}
+	ah.lastNotifyAt[repeatKey+"/"+notifierName] = time.Now()
+}
+
+// recordNotifyResult logs and counts the outcome of a single notifier
+// invocation. Counts (and the last command exit code) are kept per notifier
+// name in ah.notifierStats; this package has no accessor that exports them.
+func (ah *AlertHandler) recordNotifyResult(name string, err error) {
+	ah.notifierMu.Lock()
+	defer ah.notifierMu.Unlock()
+
+	if ah.notifierStats == nil {
+		ah.notifierStats = make(map[string]*notifierStat)
+	}
+	stat := ah.notifierStats[name]
+	if stat == nil {
+		stat = &notifierStat{}
+		ah.notifierStats[name] = stat
+	}
+
+	if err != nil {
+		stat.failure++
+		if exitErr, ok := err.(*cmdExitError); ok {
+			stat.lastExitCode = exitErr.code
+		}
+		ah.l.Errorf("alert notifier (%s) failed: %v", name, err)
+		return
+	}
+	stat.success++
+}
+
+// notifierStat tracks success/failure counts for a single notifier.
+type notifierStat struct {
+	success      int64
+	failure      int64
+	lastExitCode int
+}
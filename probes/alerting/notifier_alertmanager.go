@@ -0,0 +1,137 @@
+// Copyright 2023 The Cloudprober Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	alertingpb "github.com/cloudprober/cloudprober/probes/alerting/proto"
+)
+
+// alertmanagerAlert is a single alert in Alertmanager's v2 API JSON shape:
+// https://github.com/prometheus/alertmanager/blob/main/api/v2/openapi.yaml
+type alertmanagerAlert struct {
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     string            `json:"startsAt"`
+	EndsAt       string            `json:"endsAt,omitempty"`
+	GeneratorURL string            `json:"generatorURL,omitempty"`
+}
+
+// alertmanagerNotifier POSTs alerts to one or more upstream Alertmanager
+// instances. It implements both Notifier (one alert at a time) and
+// batchNotifier (a pre-grouped batch from the routing tree). It has no
+// client-level timeout of its own: the ctx passed to Notify/notifyBatch
+// (bounded by the notifier's configured timeout_sec) governs how long a
+// request is allowed to run, same as every other notifier in this package.
+type alertmanagerNotifier struct {
+	urls   []string
+	client *http.Client
+}
+
+func newAlertmanagerNotifier(cfg *alertingpb.AlertmanagerConfig) *alertmanagerNotifier {
+	return &alertmanagerNotifier{
+		urls:   cfg.GetUrls(),
+		client: &http.Client{},
+	}
+}
+
+func (n *alertmanagerNotifier) Name() string { return "alertmanager" }
+
+func (n *alertmanagerNotifier) Notify(ctx context.Context, fields map[string]string) error {
+	return n.notifyBatch(ctx, []map[string]string{fields})
+}
+
+// notifyBatch translates each field map into an Alertmanager alert --
+// target.label.* entries (prefix stripped) and the alert, probe and target
+// fields become labels, everything else except the combined "json" field
+// becomes an annotation -- and POSTs the whole batch as a single payload per
+// upstream URL.
+func (n *alertmanagerNotifier) notifyBatch(ctx context.Context, batch []map[string]string) error {
+	alerts := make([]alertmanagerAlert, 0, len(batch))
+	for _, fields := range batch {
+		alerts = append(alerts, fieldsToAlertmanagerAlert(fields))
+	}
+
+	body, err := json.Marshal(alerts)
+	if err != nil {
+		return fmt.Errorf("error marshalling alertmanager payload: %v", err)
+	}
+
+	var errs []string
+	for _, url := range n.urls {
+		if err := n.post(ctx, url, body); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("alertmanager push failed for some URLs: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (n *alertmanagerNotifier) post(ctx context.Context, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating alertmanager request for %s: %v", url, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error posting to alertmanager (%s): %v", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("alertmanager (%s) returned status %d", url, resp.StatusCode)
+	}
+	return nil
+}
+
+func fieldsToAlertmanagerAlert(fields map[string]string) alertmanagerAlert {
+	labels := make(map[string]string)
+	annotations := make(map[string]string)
+
+	for k, v := range fields {
+		if k == "json" {
+			continue
+		}
+		if strings.HasPrefix(k, "target.label.") {
+			labels[strings.TrimPrefix(k, "target.label.")] = v
+			continue
+		}
+		if k == "alert" || k == "probe" || k == "target" {
+			labels[k] = v
+			continue
+		}
+		annotations[k] = v
+	}
+
+	alert := alertmanagerAlert{
+		Labels:      labels,
+		Annotations: annotations,
+		StartsAt:    fields["since"],
+	}
+	if fields["status"] == string(StatusResolved) {
+		alert.EndsAt = fields["resolved_at"]
+	}
+	return alert
+}
@@ -0,0 +1,139 @@
+// Copyright 2023 The Cloudprober Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"mime/multipart"
+	"net/smtp"
+	"strings"
+
+	"github.com/cloudprober/cloudprober/common/strtemplate"
+	alertingpb "github.com/cloudprober/cloudprober/probes/alerting/proto"
+)
+
+const (
+	defaultEmailSubjectTemplate = "[cloudprober] alert {{.alert}} on {{.target}}"
+	defaultEmailBodyTemplate    = "Alert {{.alert}} fired for target {{.target}}: {{.failures}}/{{.total}} failures since {{.since}}.\n"
+)
+
+// emailNotifier sends alert emails over SMTP, as plain text or, when an
+// HTML template is configured, multipart/alternative text+HTML.
+type emailNotifier struct {
+	cfg             *alertingpb.EmailConfig
+	subjectTemplate string
+	bodyTemplate    string
+	htmlTemplate    string
+}
+
+func newEmailNotifier(cfg *alertingpb.EmailConfig, template string) *emailNotifier {
+	subjectTemplate := cfg.GetSubjectTemplate()
+	if subjectTemplate == "" {
+		subjectTemplate = defaultEmailSubjectTemplate
+	}
+
+	bodyTemplate := cfg.GetBodyTemplate()
+	if bodyTemplate == "" {
+		bodyTemplate = template
+	}
+	if bodyTemplate == "" {
+		bodyTemplate = defaultEmailBodyTemplate
+	}
+
+	return &emailNotifier{
+		cfg:             cfg,
+		subjectTemplate: subjectTemplate,
+		bodyTemplate:    bodyTemplate,
+		htmlTemplate:    cfg.GetHtmlBodyTemplate(),
+	}
+}
+
+func (n *emailNotifier) Name() string { return "email" }
+
+func (n *emailNotifier) Notify(ctx context.Context, fields map[string]string) error {
+	msg, err := n.buildMessage(fields)
+	if err != nil {
+		return err
+	}
+
+	addr := fmt.Sprintf("%s:%d", n.cfg.GetSmtpServer(), n.cfg.GetSmtpPort())
+
+	var auth smtp.Auth
+	if n.cfg.GetUsername() != "" {
+		auth = smtp.PlainAuth("", n.cfg.GetUsername(), n.cfg.GetPassword(), n.cfg.GetSmtpServer())
+	}
+
+	// net/smtp.SendMail has no deadline/cancellation hook of its own, so run
+	// it in a goroutine and give up waiting (though it keeps running in the
+	// background) once ctx is done, to honor the notifier's configured
+	// timeout instead of blocking that goroutine indefinitely.
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(addr, auth, n.cfg.GetFrom(), n.cfg.GetTo(), msg)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("error sending alert email: %v", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("sending alert email to %s timed out: %v", addr, ctx.Err())
+	}
+}
+
+func (n *emailNotifier) buildMessage(fields map[string]string) ([]byte, error) {
+	subject, _ := strtemplate.SubstituteLabels(n.subjectTemplate, fields)
+	body, _ := strtemplate.SubstituteLabels(n.bodyTemplate, fields)
+
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", n.cfg.GetFrom())
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(n.cfg.GetTo(), ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&msg, "MIME-Version: 1.0\r\n")
+
+	if n.htmlTemplate == "" {
+		msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+		msg.WriteString(body)
+		return []byte(msg.String()), nil
+	}
+
+	html, _ := strtemplate.SubstituteLabels(n.htmlTemplate, fields)
+
+	var buf strings.Builder
+	mw := multipart.NewWriter(&buf)
+	fmt.Fprintf(&msg, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", mw.Boundary())
+
+	textPart, err := mw.CreatePart(map[string][]string{"Content-Type": {"text/plain; charset=UTF-8"}})
+	if err != nil {
+		return nil, fmt.Errorf("error creating email text part: %v", err)
+	}
+	textPart.Write([]byte(body))
+
+	htmlPart, err := mw.CreatePart(map[string][]string{"Content-Type": {"text/html; charset=UTF-8"}})
+	if err != nil {
+		return nil, fmt.Errorf("error creating email html part: %v", err)
+	}
+	htmlPart.Write([]byte(html))
+
+	if err := mw.Close(); err != nil {
+		return nil, fmt.Errorf("error closing email multipart writer: %v", err)
+	}
+
+	msg.WriteString(buf.String())
+	return []byte(msg.String()), nil
+}
@@ -0,0 +1,59 @@
+// Copyright 2023 The Cloudprober Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	alertingpb "github.com/cloudprober/cloudprober/probes/alerting/proto"
+)
+
+// jsonFileNotifier appends one JSON object per alert event (firing and
+// resolved) to a file, one per line, so alerts are machine-parseable
+// without needing a notifier backend.
+type jsonFileNotifier struct {
+	path string
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+func newJSONFileNotifier(cfg *alertingpb.JSONFileConfig) *jsonFileNotifier {
+	return &jsonFileNotifier{path: cfg.GetPath()}
+}
+
+func (n *jsonFileNotifier) Name() string { return "json_file" }
+
+func (n *jsonFileNotifier) Notify(ctx context.Context, fields map[string]string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.file == nil {
+		f, err := os.OpenFile(n.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return fmt.Errorf("error opening alert json log (%s): %v", n.path, err)
+		}
+		n.file = f
+	}
+
+	line := fields["json"] + "\n"
+	if _, err := n.file.WriteString(line); err != nil {
+		return fmt.Errorf("error writing alert json log (%s): %v", n.path, err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2023 The Cloudprober Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	alertingpb "github.com/cloudprober/cloudprober/probes/alerting/proto"
+)
+
+// pagerdutyNotifier sends PagerDuty Events v2 trigger events. The dedup_key
+// is derived from condition_id+target so that repeated firings of the same
+// alert on the same target coalesce into a single PagerDuty incident.
+type pagerdutyNotifier struct {
+	cfg *alertingpb.PagerdutyConfig
+}
+
+func newPagerdutyNotifier(cfg *alertingpb.PagerdutyConfig) *pagerdutyNotifier {
+	return &pagerdutyNotifier{cfg: cfg}
+}
+
+func (n *pagerdutyNotifier) Name() string { return "pagerduty" }
+
+type pagerdutyEvent struct {
+	RoutingKey  string                 `json:"routing_key"`
+	EventAction string                 `json:"event_action"`
+	DedupKey    string                 `json:"dedup_key"`
+	Payload     *pagerdutyEventPayload `json:"payload,omitempty"`
+}
+
+type pagerdutyEventPayload struct {
+	Summary       string            `json:"summary"`
+	Source        string            `json:"source"`
+	Severity      string            `json:"severity"`
+	CustomDetails map[string]string `json:"custom_details"`
+}
+
+func (n *pagerdutyNotifier) Notify(ctx context.Context, fields map[string]string) error {
+	dedupKey := fields["condition_id"] + "+" + fields["target"]
+
+	event := pagerdutyEvent{
+		RoutingKey: n.cfg.GetRoutingKey(),
+		DedupKey:   dedupKey,
+	}
+
+	if fields["status"] == string(StatusResolved) {
+		event.EventAction = "resolve"
+	} else {
+		event.EventAction = "trigger"
+		event.Payload = &pagerdutyEventPayload{
+			Summary:       fmt.Sprintf("Alert %s on target %s: %s/%s failures", fields["alert"], fields["target"], fields["failures"], fields["total"]),
+			Source:        fields["probe"],
+			Severity:      "critical",
+			CustomDetails: fields,
+		}
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("error marshalling pagerduty event: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.cfg.GetApiUrl(), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating pagerduty request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error posting to pagerduty: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("pagerduty events API returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2023 The Cloudprober Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/cloudprober/cloudprober/common/strtemplate"
+	alertingpb "github.com/cloudprober/cloudprober/probes/alerting/proto"
+)
+
+const defaultSlackTemplate = ":rotating_light: *Alert {{.alert}}* on target `{{.target}}`: {{.failures}}/{{.total}} failures since {{.since}}"
+
+// slackNotifier posts a block-kit formatted message to a Slack incoming
+// webhook URL.
+type slackNotifier struct {
+	cfg      *alertingpb.SlackConfig
+	template string
+}
+
+func newSlackNotifier(cfg *alertingpb.SlackConfig, template string) *slackNotifier {
+	if template == "" {
+		template = defaultSlackTemplate
+	}
+	return &slackNotifier{cfg: cfg, template: template}
+}
+
+func (n *slackNotifier) Name() string { return "slack" }
+
+func (n *slackNotifier) Notify(ctx context.Context, fields map[string]string) error {
+	text, _ := strtemplate.SubstituteLabels(n.template, fields)
+
+	payload := map[string]interface{}{
+		"text": text,
+		"blocks": []map[string]interface{}{
+			{
+				"type": "section",
+				"text": map[string]string{
+					"type": "mrkdwn",
+					"text": text,
+				},
+			},
+		},
+	}
+	if n.cfg.GetChannel() != "" {
+		payload["channel"] = n.cfg.GetChannel()
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("error marshalling slack payload: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.cfg.GetWebhookUrl(), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating slack request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error posting to slack: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("slack webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
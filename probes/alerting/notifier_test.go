@@ -0,0 +1,54 @@
+// Copyright 2023 The Cloudprober Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alerting
+
+import (
+	"testing"
+	"time"
+
+	alertingpb "github.com/cloudprober/cloudprober/probes/alerting/proto"
+)
+
+func TestDueForRepeat(t *testing.T) {
+	noRepeat := &alertingpb.NotifyConfig{}
+	repeatEvery10s := &alertingpb.NotifyConfig{RepeatIntervalSec: protoInt32(10)}
+
+	ah := &AlertHandler{}
+	if ah.dueForRepeat("cond+target", "slack", noRepeat) {
+		t.Errorf("dueForRepeat() = true for a notifier with no repeat_interval_sec configured")
+	}
+
+	if !ah.dueForRepeat("cond+target", "slack", repeatEvery10s) {
+		t.Errorf("dueForRepeat() = false on first check, want true (never notified before)")
+	}
+
+	ah.markNotified("cond+target", "slack")
+	if ah.dueForRepeat("cond+target", "slack", repeatEvery10s) {
+		t.Errorf("dueForRepeat() = true immediately after markNotified, want false")
+	}
+
+	ah.notifierMu.Lock()
+	ah.lastNotifyAt["cond+target/slack"] = time.Now().Add(-20 * time.Second)
+	ah.notifierMu.Unlock()
+	if !ah.dueForRepeat("cond+target", "slack", repeatEvery10s) {
+		t.Errorf("dueForRepeat() = false once the repeat interval has elapsed, want true")
+	}
+
+	if !ah.dueForRepeat("cond+target", "pagerduty", repeatEvery10s) {
+		t.Errorf("dueForRepeat() = false for a notifier that has never notified this repeatKey, want true")
+	}
+}
+
+func protoInt32(i int32) *int32 { return &i }
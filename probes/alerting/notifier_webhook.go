@@ -0,0 +1,164 @@
+// Copyright 2023 The Cloudprober Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cloudprober/cloudprober/common/strtemplate"
+	alertingpb "github.com/cloudprober/cloudprober/probes/alerting/proto"
+)
+
+// webhookNotifier POSTs (or otherwise sends, per method) a JSON body to an
+// arbitrary HTTP endpoint, retrying transient failures. With no configured
+// body_template, the body is a fixed JSON object built from alertFields
+// directly (like notifier_slack.go and notifier_pagerduty.go); with one,
+// each substituted field value is JSON-escaped first so a value containing
+// a quote, backslash, or newline can't produce invalid JSON or inject extra
+// keys into the payload.
+type webhookNotifier struct {
+	cfg          *alertingpb.WebhookConfig
+	bodyTemplate string
+	client       *http.Client
+}
+
+func newWebhookNotifier(cfg *alertingpb.WebhookConfig, bodyTemplate string) *webhookNotifier {
+	// Sink-specific body_template wins over the generic NotifyConfig.template,
+	// matching notifier_email.go's precedence.
+	bt := cfg.GetBodyTemplate()
+	if bt == "" {
+		bt = bodyTemplate
+	}
+	bodyTemplate = bt
+
+	transport := &http.Transport{}
+	if cfg.GetTlsSkipVerify() {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	return &webhookNotifier{
+		cfg:          cfg,
+		bodyTemplate: bodyTemplate,
+		client:       &http.Client{Transport: transport},
+	}
+}
+
+func (n *webhookNotifier) Name() string { return "webhook" }
+
+func (n *webhookNotifier) Notify(ctx context.Context, fields map[string]string) error {
+	body, err := n.buildBody(fields)
+	if err != nil {
+		return err
+	}
+
+	method := n.cfg.GetMethod()
+	if method == "" {
+		method = http.MethodPost
+	}
+
+	retries := int(n.cfg.GetRetries())
+	var lastErr error
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Duration(attempt) * time.Second):
+			}
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, n.cfg.GetUrl(), bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("error creating webhook request: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		for k, v := range n.cfg.GetHeaders() {
+			req.Header.Set(k, v)
+		}
+
+		resp, err := n.client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode >= 500 {
+			lastErr = fmt.Errorf("webhook %s returned status %d", n.cfg.GetUrl(), resp.StatusCode)
+			continue
+		}
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("webhook %s returned status %d", n.cfg.GetUrl(), resp.StatusCode)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("webhook %s failed after %d retries: %v", n.cfg.GetUrl(), retries, lastErr)
+}
+
+// buildBody constructs the JSON request body. With no configured template,
+// it builds a fixed payload from a handful of well-known fields directly,
+// the same way notifier_slack.go and notifier_pagerduty.go build their
+// payloads. With a template, it substitutes into it, but first JSON-escapes
+// every field value so a value coming from untrusted target metadata can't
+// break out of its string or inject extra keys.
+func (n *webhookNotifier) buildBody(fields map[string]string) ([]byte, error) {
+	if n.bodyTemplate == "" {
+		payload := map[string]interface{}{
+			"alert":  fields["alert"],
+			"target": fields["target"],
+			"probe":  fields["probe"],
+		}
+		if v, err := strconv.Atoi(fields["failures"]); err == nil {
+			payload["failures"] = v
+		} else {
+			payload["failures"] = fields["failures"]
+		}
+		if v, err := strconv.Atoi(fields["total"]); err == nil {
+			payload["total"] = v
+		} else {
+			payload["total"] = fields["total"]
+		}
+		body, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("error marshalling webhook payload: %v", err)
+		}
+		return body, nil
+	}
+
+	escaped := make(map[string]string, len(fields))
+	for k, v := range fields {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("error escaping webhook field %q: %v", k, err)
+		}
+		// Drop only the enclosing quotes json.Marshal added; an escaped quote
+		// at either end of the value must stay.
+		escaped[k] = string(b[1 : len(b)-1])
+	}
+
+	body, foundAll := strtemplate.SubstituteLabels(n.bodyTemplate, escaped)
+	if !foundAll {
+		return nil, fmt.Errorf("couldn't substitute all labels in webhook body template: %s", n.bodyTemplate)
+	}
+	return []byte(body), nil
+}
@@ -18,14 +18,20 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os/exec"
 	"strconv"
-	"strings"
 	"time"
 
-	"github.com/cloudprober/cloudprober/common/strtemplate"
 	"github.com/cloudprober/cloudprober/targets/endpoint"
-	"github.com/google/shlex"
+)
+
+// AlertStatus is the lifecycle state of an alert: it starts out firing and
+// moves to resolved once the target's failures drop back below the
+// condition.
+type AlertStatus string
+
+const (
+	StatusFiring   AlertStatus = "firing"
+	StatusResolved AlertStatus = "resolved"
 )
 
 // AlertInfo contains information about an alert.
@@ -37,6 +43,8 @@ type AlertInfo struct {
 	Failures     int
 	Total        int
 	FailingSince time.Time
+	Status       AlertStatus
+	ResolvedAt   time.Time
 }
 
 func alertFields(alertInfo *AlertInfo) (map[string]string, error) {
@@ -48,6 +56,10 @@ func alertFields(alertInfo *AlertInfo) (map[string]string, error) {
 		"failures":     strconv.Itoa(alertInfo.Failures),
 		"total":        strconv.Itoa(alertInfo.Total),
 		"since":        alertInfo.FailingSince.Format(time.RFC3339),
+		"status":       string(alertInfo.Status),
+	}
+	if !alertInfo.ResolvedAt.IsZero() {
+		fields["resolved_at"] = alertInfo.ResolvedAt.Format(time.RFC3339)
 	}
 
 	for k, v := range alertInfo.Target.Labels {
@@ -65,8 +77,7 @@ func alertFields(alertInfo *AlertInfo) (map[string]string, error) {
 }
 
 func (ah *AlertHandler) notify(ep endpoint.Endpoint, ts *targetState, totalFailures int) {
-	ah.l.Warningf("ALERT (%s): target (%s), failures (%d) higher than (%d) since (%v)", ah.name, ep.Name, totalFailures, ah.condition.Failures, ts.failingSince)
-
+	wasAlerted := ts.alerted
 	ts.alerted = true
 	alertInfo := &AlertInfo{
 		Name:         ah.name,
@@ -76,6 +87,7 @@ func (ah *AlertHandler) notify(ep endpoint.Endpoint, ts *targetState, totalFailu
 		Failures:     totalFailures,
 		Total:        int(ah.condition.Total),
 		FailingSince: ts.failingSince,
+		Status:       StatusFiring,
 	}
 
 	if ah.notifyCh != nil {
@@ -86,36 +98,65 @@ func (ah *AlertHandler) notify(ep endpoint.Endpoint, ts *targetState, totalFailu
 	if err != nil {
 		ah.l.Errorf("Error getting alert fields: %v", err)
 	}
+	ah.logStructuredAlert(fields)
 
-	if ah.notifyConfig != nil && ah.notifyConfig.Command != "" {
-		ah.notifyCommand(context.Background(), ah.notifyConfig.Command, fields, false)
+	if ah.silences != nil && ah.silences.Matches(fields, time.Now()) {
+		ah.l.Infof("alert (%s) on target (%s) is silenced, not notifying", ah.name, ep.Name)
+		return
 	}
-}
 
-func (ah *AlertHandler) notifyCommand(ctx context.Context, command string, fields map[string]string, dryRun bool) []string {
-	res, foundAll := strtemplate.SubstituteLabels(command, fields)
-	if !foundAll {
-		ah.l.Warningf("couldn't substitute all labels in command: %s", command)
+	if ah.router != nil {
+		ah.router.Route(fields)
+		return
 	}
-	command = res
 
-	cmdParts, err := shlex.Split(command)
-	if err != nil {
-		ah.l.Errorf("Error parsing command line (%s): %v", command, err)
-		return nil
+	// wasAlerted is true on every evaluation after the first while the
+	// target keeps failing; dispatch only re-sends to notifiers whose
+	// RepeatInterval has elapsed in that case.
+	ah.dispatch(context.Background(), ts.conditionID+"+"+ep.Dst(), fields, wasAlerted)
+}
+
+// resolve fires a "resolved" notification once a previously firing target's
+// failures drop back below the alert condition, then clears the target's
+// firing state so a future failure starts a fresh alert.
+func (ah *AlertHandler) resolve(ep endpoint.Endpoint, ts *targetState, totalFailures int) {
+	if !ts.alerted {
+		return
 	}
+	ts.alerted = false
 
-	ah.l.Infof("Starting external command: %s", strings.Join(cmdParts, " "))
+	now := time.Now()
+	alertInfo := &AlertInfo{
+		Name:         ah.name,
+		ProbeName:    ah.probeName,
+		ConditionID:  ts.conditionID,
+		Target:       ep,
+		Failures:     totalFailures,
+		Total:        int(ah.condition.Total),
+		FailingSince: ts.failingSince,
+		Status:       StatusResolved,
+		ResolvedAt:   now,
+	}
 
-	cmd := exec.CommandContext(ctx, cmdParts[0], cmdParts[1:]...)
+	if ah.notifyCh != nil {
+		ah.notifyCh <- alertInfo
+	}
 
-	if dryRun {
-		return cmd.Args
+	fields, err := alertFields(alertInfo)
+	if err != nil {
+		ah.l.Errorf("Error getting alert fields: %v", err)
+		return
 	}
 
-	if err = cmd.Start(); err != nil {
-		ah.l.Errorf("error while starting the cmd: %s %s. Err: %v", cmd.Path, cmd.Args, err)
+	ah.logStructuredAlert(fields)
+
+	if ah.silences != nil && ah.silences.Matches(fields, now) {
+		return
 	}
 
-	return nil
+	if ah.router != nil {
+		ah.router.Route(fields)
+		return
+	}
+	ah.dispatch(context.Background(), ts.conditionID+"+"+ep.Dst(), fields, false)
 }
@@ -0,0 +1,269 @@
+// Copyright 2023 The Cloudprober Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alerting
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudprober/cloudprober/logger"
+	alertingpb "github.com/cloudprober/cloudprober/probes/alerting/proto"
+)
+
+// batchNotifier is implemented by notifiers that can send a group of alerts
+// as a single merged payload (currently just alertmanagerNotifier). Routed
+// notifiers that don't implement it receive one Notify call per alert in
+// the group instead.
+type batchNotifier interface {
+	notifyBatch(ctx context.Context, batch []map[string]string) error
+}
+
+// route is the runtime (compiled) form of a alertingpb.Route: regexes
+// parsed, durations converted, and matched depth-first against a route's
+// children before falling back to the route itself.
+type route struct {
+	receiver      string
+	match         []compiledMatcher
+	groupBy       []string
+	groupWait     time.Duration
+	groupInterval time.Duration
+	children      []*route
+}
+
+type compiledMatcher struct {
+	label string
+	re    *regexp.Regexp
+}
+
+func compileRoute(cfg *alertingpb.Route) *route {
+	if cfg == nil {
+		return nil
+	}
+
+	r := &route{
+		receiver: cfg.GetReceiver(),
+		groupBy:  cfg.GetGroupBy(),
+	}
+	// Leave unset durations at 0 (not the proto defaults) so resolve() can
+	// inherit them from an ancestor route.
+	if cfg.GroupWaitSec != nil {
+		r.groupWait = time.Duration(cfg.GetGroupWaitSec()) * time.Second
+	}
+	if cfg.GroupIntervalSec != nil {
+		r.groupInterval = time.Duration(cfg.GetGroupIntervalSec()) * time.Second
+	}
+	for _, m := range cfg.GetMatch() {
+		re, err := regexp.Compile(m.GetRegex())
+		if err != nil {
+			continue
+		}
+		r.match = append(r.match, compiledMatcher{label: m.GetLabel(), re: re})
+	}
+	for _, child := range cfg.GetRoutes() {
+		r.children = append(r.children, compileRoute(child))
+	}
+	return r
+}
+
+func (r *route) matches(fields map[string]string) bool {
+	for _, m := range r.match {
+		if !m.re.MatchString(fields[m.label]) {
+			return false
+		}
+	}
+	return true
+}
+
+// resolve walks the routing tree depth-first and returns the most specific
+// matching route, inheriting group_by/group_wait/group_interval from
+// ancestors when a child route doesn't override them. The returned *route
+// is always a private copy: resolve never hands back a pointer into the
+// shared, read-only tree built by compileRoute, so callers (router.Route,
+// called concurrently for every alert) can't race on the same node.
+func (r *route) resolve(fields map[string]string) *route {
+	for _, child := range r.children {
+		if !child.matches(fields) {
+			continue
+		}
+		resolved := child.resolve(fields)
+		if len(resolved.groupBy) == 0 {
+			resolved.groupBy = r.groupBy
+		}
+		if resolved.groupWait == 0 {
+			resolved.groupWait = r.groupWait
+		}
+		if resolved.groupInterval == 0 {
+			resolved.groupInterval = r.groupInterval
+		}
+		if resolved.receiver == "" {
+			resolved.receiver = r.receiver
+		}
+		return resolved
+	}
+
+	cp := *r
+	return &cp
+}
+
+// maxIdleFlushes is how many consecutive empty flushes (no new alerts since
+// the last send) a group tolerates before its timer stops rescheduling and
+// the group is evicted. Without this, a group that fires once keeps an
+// AfterFunc timer re-firing every group_interval forever, even after the
+// alert it was created for has long since resolved and will never recur.
+const maxIdleFlushes = 2
+
+// alertGroup buffers alerts sharing a group key until group_wait (for a new
+// group) or group_interval (for a group that already notified) elapses.
+type alertGroup struct {
+	fields      []map[string]string
+	timer       *time.Timer
+	notified    bool
+	idleFlushes int
+}
+
+// routedNotifier pairs a constructed Notifier with the NotifyConfig it was
+// built from, so the router can honor that notifier's configured
+// timeout_sec the same way the non-routed dispatch() path does.
+type routedNotifier struct {
+	notifier Notifier
+	nc       *alertingpb.NotifyConfig
+}
+
+// router groups alerts per the routing tree before handing them off to
+// receivers, batching notifications within group_wait the way Alertmanager
+// does.
+type router struct {
+	root       *route
+	receivers  map[string][]routedNotifier
+	l          *logger.Logger
+	recordFunc func(name string, err error)
+
+	mu     sync.Mutex
+	groups map[string]*alertGroup
+}
+
+func newRouter(cfg *alertingpb.Route, receivers map[string][]routedNotifier, l *logger.Logger, recordFunc func(string, error)) *router {
+	root := compileRoute(cfg)
+	if root == nil {
+		return nil
+	}
+	return &router{
+		root:       root,
+		receivers:  receivers,
+		l:          l,
+		recordFunc: recordFunc,
+		groups:     make(map[string]*alertGroup),
+	}
+}
+
+func groupKey(groupBy []string, fields map[string]string) string {
+	if len(groupBy) == 0 {
+		return fields["condition_id"] + "+" + fields["target"]
+	}
+	keys := make([]string, len(groupBy))
+	for i, label := range groupBy {
+		keys[i] = label + "=" + fields[label]
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
+
+// Route buffers fields into its matching group, scheduling (or reusing) a
+// flush timer per Alertmanager's group_wait/group_interval semantics.
+func (rt *router) Route(fields map[string]string) {
+	resolved := rt.root.resolve(fields)
+	key := resolved.receiver + "/" + groupKey(resolved.groupBy, fields)
+
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	g, ok := rt.groups[key]
+	if ok {
+		g.fields = append(g.fields, fields)
+		return
+	}
+
+	g = &alertGroup{fields: []map[string]string{fields}}
+	rt.groups[key] = g
+
+	wait := resolved.groupWait
+	if wait <= 0 {
+		wait = 30 * time.Second
+	}
+	g.timer = time.AfterFunc(wait, func() { rt.flush(key, resolved) })
+}
+
+func (rt *router) flush(key string, resolved *route) {
+	rt.mu.Lock()
+	g, ok := rt.groups[key]
+	if !ok {
+		rt.mu.Unlock()
+		return
+	}
+	batch := g.fields
+	g.fields = nil
+	g.notified = true
+
+	if len(batch) == 0 {
+		g.idleFlushes++
+		if g.idleFlushes >= maxIdleFlushes {
+			// No new alerts for several group_intervals in a row: this
+			// group's alert is gone for good (or at least not recurring
+			// soon). Stop rescheduling and drop it so groups don't
+			// accumulate forever on a long-running probe.
+			delete(rt.groups, key)
+			rt.mu.Unlock()
+			return
+		}
+	} else {
+		g.idleFlushes = 0
+	}
+
+	interval := resolved.groupInterval
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+	g.timer = time.AfterFunc(interval, func() { rt.flush(key, resolved) })
+	rt.mu.Unlock()
+
+	if len(batch) == 0 {
+		return
+	}
+
+	for _, rn := range rt.receivers[resolved.receiver] {
+		rt.send(rn, batch)
+	}
+}
+
+func (rt *router) send(rn routedNotifier, batch []map[string]string) {
+	if bn, ok := rn.notifier.(batchNotifier); ok {
+		ctx, cancel := context.WithTimeout(context.Background(), notifierTimeout(rn.nc))
+		defer cancel()
+		err := bn.notifyBatch(ctx, batch)
+		rt.recordFunc(rn.notifier.Name(), err)
+		return
+	}
+
+	for _, fields := range batch {
+		ctx, cancel := context.WithTimeout(context.Background(), notifierTimeout(rn.nc))
+		err := rn.notifier.Notify(ctx, fields)
+		cancel()
+		rt.recordFunc(rn.notifier.Name(), err)
+	}
+}
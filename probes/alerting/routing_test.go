@@ -0,0 +1,119 @@
+// Copyright 2023 The Cloudprober Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alerting
+
+import (
+	"testing"
+	"time"
+
+	alertingpb "github.com/cloudprober/cloudprober/probes/alerting/proto"
+)
+
+func testRouteTree() *route {
+	return compileRoute(&alertingpb.Route{
+		Receiver:         protoString("default"),
+		GroupWaitSec:     protoInt32(30),
+		GroupIntervalSec: protoInt32(300),
+		Routes: []*alertingpb.Route{
+			{
+				Receiver: protoString("pagerduty-prod"),
+				Match: []*alertingpb.RouteMatcher{
+					{Label: protoString("target.label.env"), Regex: protoString("^prod$")},
+				},
+				GroupBy: []string{"alert"},
+			},
+			{
+				Receiver: protoString("slack-staging"),
+				Match: []*alertingpb.RouteMatcher{
+					{Label: protoString("target.label.env"), Regex: protoString("^staging$")},
+				},
+			},
+		},
+	})
+}
+
+func TestRouteResolveMatching(t *testing.T) {
+	root := testRouteTree()
+
+	tests := []struct {
+		name         string
+		fields       map[string]string
+		wantReceiver string
+	}{
+		{"matches prod child", map[string]string{"target.label.env": "prod"}, "pagerduty-prod"},
+		{"matches staging child", map[string]string{"target.label.env": "staging"}, "slack-staging"},
+		{"falls back to root", map[string]string{"target.label.env": "dev"}, "default"},
+		{"no env label at all", map[string]string{}, "default"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolved := root.resolve(tt.fields)
+			if resolved.receiver != tt.wantReceiver {
+				t.Errorf("resolve(%v).receiver = %q, want %q", tt.fields, resolved.receiver, tt.wantReceiver)
+			}
+		})
+	}
+}
+
+func TestRouteResolveInheritance(t *testing.T) {
+	root := testRouteTree()
+
+	resolved := root.resolve(map[string]string{"target.label.env": "prod"})
+	if len(resolved.groupBy) != 1 || resolved.groupBy[0] != "alert" {
+		t.Errorf("resolved.groupBy = %v, want [\"alert\"] (child's own group_by)", resolved.groupBy)
+	}
+	if resolved.groupWait != 30*time.Second {
+		t.Errorf("resolved.groupWait = %v, want 30s inherited from root", resolved.groupWait)
+	}
+	if resolved.groupInterval != 300*time.Second {
+		t.Errorf("resolved.groupInterval = %v, want 300s inherited from root", resolved.groupInterval)
+	}
+
+	resolvedStaging := root.resolve(map[string]string{"target.label.env": "staging"})
+	if len(resolvedStaging.groupBy) != 0 {
+		t.Errorf("resolvedStaging.groupBy = %v, want empty (child overrides nothing, inherits root's unset group_by)", resolvedStaging.groupBy)
+	}
+}
+
+func TestRouteResolveReturnsCopy(t *testing.T) {
+	root := testRouteTree()
+
+	first := root.resolve(map[string]string{"target.label.env": "prod"})
+	first.groupBy = []string{"mutated"}
+	first.receiver = "mutated-receiver"
+
+	second := root.resolve(map[string]string{"target.label.env": "prod"})
+	if second.receiver != "pagerduty-prod" {
+		t.Errorf("second resolve() saw mutation from first caller's copy: receiver = %q", second.receiver)
+	}
+	if len(second.groupBy) != 1 || second.groupBy[0] != "alert" {
+		t.Errorf("second resolve() saw mutation from first caller's copy: groupBy = %v", second.groupBy)
+	}
+}
+
+func TestGroupKey(t *testing.T) {
+	fields := map[string]string{"condition_id": "c1", "target": "t1", "alert": "high-latency", "probe": "p1"}
+
+	if got, want := groupKey(nil, fields), "c1+t1"; got != want {
+		t.Errorf("groupKey(nil, ...) = %q, want %q", got, want)
+	}
+
+	got := groupKey([]string{"probe", "alert"}, fields)
+	want := groupKey([]string{"alert", "probe"}, fields)
+	if got != want {
+		t.Errorf("groupKey() not order-independent: %q vs %q", got, want)
+	}
+}
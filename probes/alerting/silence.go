@@ -0,0 +1,150 @@
+// Copyright 2023 The Cloudprober Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alerting
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/cloudprober/cloudprober/logger"
+	alertingpb "github.com/cloudprober/cloudprober/probes/alerting/proto"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// silenceStore keeps the active set of silences in memory, persisting every
+// change to diskPath so silences survive a cloudprober restart.
+type silenceStore struct {
+	mu       sync.RWMutex
+	silences map[string]*alertingpb.Silence
+	diskPath string
+	l        *logger.Logger
+}
+
+func newSilenceStore(diskPath string, l *logger.Logger) (*silenceStore, error) {
+	s := &silenceStore{
+		silences: make(map[string]*alertingpb.Silence),
+		diskPath: diskPath,
+		l:        l,
+	}
+	if diskPath == "" {
+		return s, nil
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *silenceStore) load() error {
+	data, err := os.ReadFile(s.diskPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading silences file (%s): %v", s.diskPath, err)
+	}
+
+	var list alertingpb.SilenceList
+	if err := protojson.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("error parsing silences file (%s): %v", s.diskPath, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sil := range list.GetSilences() {
+		s.silences[sil.GetId()] = sil
+	}
+	return nil
+}
+
+// persist writes the current silence set to diskPath. Callers must hold
+// s.mu (for reading).
+func (s *silenceStore) persist() error {
+	if s.diskPath == "" {
+		return nil
+	}
+
+	list := &alertingpb.SilenceList{}
+	for _, sil := range s.silences {
+		list.Silences = append(list.Silences, sil)
+	}
+
+	data, err := protojson.Marshal(list)
+	if err != nil {
+		return fmt.Errorf("error marshalling silences: %v", err)
+	}
+	return os.WriteFile(s.diskPath, data, 0644)
+}
+
+// Add inserts or replaces a silence and persists the new set to disk.
+func (s *silenceStore) Add(sil *alertingpb.Silence) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.silences[sil.GetId()] = sil
+	return s.persist()
+}
+
+// Remove deletes a silence by ID and persists the new set to disk.
+func (s *silenceStore) Remove(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.silences, id)
+	return s.persist()
+}
+
+// List returns all known silences, expired or not.
+func (s *silenceStore) List() []*alertingpb.Silence {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make([]*alertingpb.Silence, 0, len(s.silences))
+	for _, sil := range s.silences {
+		out = append(out, sil)
+	}
+	return out
+}
+
+// Matches reports whether fields (the same map passed to notifiers) is
+// covered by an active silence at the given time.
+func (s *silenceStore) Matches(fields map[string]string, now time.Time) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, sil := range s.silences {
+		if silenceActive(sil, now) && silenceMatchesFields(sil, fields) {
+			return true
+		}
+	}
+	return false
+}
+
+func silenceActive(sil *alertingpb.Silence, now time.Time) bool {
+	starts := time.Unix(sil.GetStartsAtUnix(), 0)
+	ends := time.Unix(sil.GetEndsAtUnix(), 0)
+	return !now.Before(starts) && now.Before(ends)
+}
+
+func silenceMatchesFields(sil *alertingpb.Silence, fields map[string]string) bool {
+	for _, m := range sil.GetMatchers() {
+		if fields[m.GetField()] != m.GetValue() {
+			return false
+		}
+	}
+	return len(sil.GetMatchers()) > 0
+}
@@ -0,0 +1,117 @@
+// Copyright 2023 The Cloudprober Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alerting
+
+import (
+	"io"
+	"net/http"
+	"strings"
+
+	alertingpb "github.com/cloudprober/cloudprober/probes/alerting/proto"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// RegisterSilenceHandlers wires up the /silences HTTP API on mux:
+//
+//	GET    /silences       -- list all silences
+//	POST   /silences       -- create a silence from a JSON body
+//	DELETE /silences/<id>  -- remove a silence
+func (ah *AlertHandler) RegisterSilenceHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/silences", ah.handleSilences)
+	mux.HandleFunc("/silences/", ah.handleSilence)
+}
+
+func (ah *AlertHandler) handleSilences(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		ah.writeSilenceList(w)
+	case http.MethodPost:
+		ah.createSilence(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (ah *AlertHandler) handleSilence(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/silences/")
+	if id == "" {
+		http.Error(w, "missing silence id", http.StatusBadRequest)
+		return
+	}
+
+	if ah.silences == nil {
+		http.Error(w, "silences are not configured for this alert", http.StatusServiceUnavailable)
+		return
+	}
+
+	if err := ah.silences.Remove(id); err != nil {
+		ah.l.Errorf("error removing silence (%s): %v", id, err)
+		http.Error(w, "error removing silence", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (ah *AlertHandler) writeSilenceList(w http.ResponseWriter) {
+	if ah.silences == nil {
+		http.Error(w, "silences are not configured for this alert", http.StatusServiceUnavailable)
+		return
+	}
+
+	list := &alertingpb.SilenceList{Silences: ah.silences.List()}
+	data, err := protojson.Marshal(list)
+	if err != nil {
+		ah.l.Errorf("error marshalling silences: %v", err)
+		http.Error(w, "error marshalling silences", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+func (ah *AlertHandler) createSilence(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "error reading request body", http.StatusBadRequest)
+		return
+	}
+
+	sil := &alertingpb.Silence{}
+	if err := protojson.Unmarshal(body, sil); err != nil {
+		http.Error(w, "error parsing silence: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if sil.GetId() == "" {
+		http.Error(w, "silence id is required", http.StatusBadRequest)
+		return
+	}
+
+	if ah.silences == nil {
+		http.Error(w, "silences are not configured for this alert", http.StatusServiceUnavailable)
+		return
+	}
+
+	if err := ah.silences.Add(sil); err != nil {
+		ah.l.Errorf("error saving silence (%s): %v", sil.GetId(), err)
+		http.Error(w, "error saving silence", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
@@ -0,0 +1,104 @@
+// Copyright 2023 The Cloudprober Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alerting
+
+import (
+	"testing"
+	"time"
+
+	alertingpb "github.com/cloudprober/cloudprober/probes/alerting/proto"
+)
+
+func TestSilenceActive(t *testing.T) {
+	now := time.Date(2023, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		startsAt time.Time
+		endsAt   time.Time
+		want     bool
+	}{
+		{"before window", now.Add(time.Hour), now.Add(2 * time.Hour), false},
+		{"after window", now.Add(-2 * time.Hour), now.Add(-time.Hour), false},
+		{"inside window", now.Add(-time.Hour), now.Add(time.Hour), true},
+		{"exactly at start", now, now.Add(time.Hour), true},
+		{"exactly at end", now.Add(-time.Hour), now, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sil := &alertingpb.Silence{
+				StartsAtUnix: protoInt64(tt.startsAt.Unix()),
+				EndsAtUnix:   protoInt64(tt.endsAt.Unix()),
+			}
+			if got := silenceActive(sil, now); got != tt.want {
+				t.Errorf("silenceActive() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSilenceMatchesFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		matchers []*alertingpb.SilenceMatcher
+		fields   map[string]string
+		want     bool
+	}{
+		{
+			name:     "no matchers never matches",
+			matchers: nil,
+			fields:   map[string]string{"alert": "high-latency"},
+			want:     false,
+		},
+		{
+			name: "single matcher, matches",
+			matchers: []*alertingpb.SilenceMatcher{
+				{Field: protoString("alert"), Value: protoString("high-latency")},
+			},
+			fields: map[string]string{"alert": "high-latency", "target": "foo"},
+			want:   true,
+		},
+		{
+			name: "single matcher, no match",
+			matchers: []*alertingpb.SilenceMatcher{
+				{Field: protoString("alert"), Value: protoString("high-latency")},
+			},
+			fields: map[string]string{"alert": "low-latency"},
+			want:   false,
+		},
+		{
+			name: "all matchers must match",
+			matchers: []*alertingpb.SilenceMatcher{
+				{Field: protoString("alert"), Value: protoString("high-latency")},
+				{Field: protoString("target.label.env"), Value: protoString("prod")},
+			},
+			fields: map[string]string{"alert": "high-latency", "target.label.env": "staging"},
+			want:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sil := &alertingpb.Silence{Matchers: tt.matchers}
+			if got := silenceMatchesFields(sil, tt.fields); got != tt.want {
+				t.Errorf("silenceMatchesFields() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func protoString(s string) *string { return &s }
+func protoInt64(i int64) *int64    { return &i }